@@ -9,9 +9,11 @@ package main
 import (
 	"./utils/cache"
 	"./utils/http"
+	"context"
 	"crypto/md5"
 	"flag"
 	"fmt"
+	"path/filepath"
 )
 
 const (
@@ -20,99 +22,110 @@ const (
 	URL1 = "/images/landing/Rollercoaster/whatsroblox_12072017.jpg"
 	URL2 = "/images/landing/Rollercoaster/gameimage3_12072017.jpg"
 	URL3 = "/images/landing/Rollercoaster/devices_people_12072017.png"
-
-	// HTTP headers
-	IF_NONE_MATCH     = "If-None-Match"
-	IF_MODIFIED_SINCE = "If-Modified-Since"
-	ETAG              = "Etag"
-	LAST_MODIFIED     = "Last-Modified"
-
-	// Cache keys
-	FILE_BYTES = "file_bytes"
-	FILE_HASH  = "file_hash"
 )
 
 func main() {
 	// Command line args
 	var pverbose = flag.Bool("verbose", false, "Enable verbose output")
+	var ppolicy = flag.String("policy", "lru",
+		"Eviction policy to use: lru, lfu, 2q, arc, or sieve")
+	var pcachesize = flag.String("cache-size", "200KB",
+		"In-memory cache size per shard, e.g. 64MB, 1GB")
+	var pdiskdir = flag.String("disk-dir", "",
+		"Directory for the on-disk persistent cache tier (disabled if empty)")
+	var pdisksize = flag.String("disk-size", "64MB",
+		"Max on-disk cache size per shard, e.g. 64MB, 1GB")
+	var pshards = flag.Int("shards", 1,
+		"Number of cache shards, for concurrent fetchers (1 disables sharding)")
 	flag.Parse()
 
-	// Our header and file content cache.
-	cache := cache.New(200*1024, pverbose)
+	maxBytes, err := cache.ParseSize(*pcachesize)
+	if nil != err {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	// Validate the policy name up front; each shard builds its own below.
+	_, err = cache.NewPolicyByName[string](*ppolicy, 3)
+	if nil != err {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	opts := []cache.Option[string, *http.Response]{
+		cache.WithVerbose[string, *http.Response](*pverbose),
+		// NewSharded applies these options to every shard, so the Policy is
+		// constructed per shard rather than shared between them.
+		func(c *cache.Cache[string, *http.Response]) {
+			policy, _ := cache.NewPolicyByName[string](*ppolicy, 3)
+			cache.WithPolicy[string, *http.Response](policy)(c)
+		},
+	}
+	if "" != *pdiskdir {
+		// Cached responses (headers, body, and the Cache-Control/ETag/
+		// Last-Modified bookkeeping Client needs) survive a restart via the
+		// same manifest-backed disk tier, so 304 revalidation keeps working
+		// across process runs.
+		diskBytes, err := cache.ParseSize(*pdisksize)
+		if nil != err {
+			fmt.Println("Error:", err)
+			return
+		}
+		opts = append(opts,
+			cache.WithDiskStore[string, *http.Response](filepath.Join(*pdiskdir, "responses"), diskBytes))
+	}
+
+	// A ShardedCache of 1 shard behaves identically to a single Cache, so
+	// --shards can default to 1 without main needing a separate code path.
+	responses := cache.NewSharded[string, *http.Response](*pshards, int(maxBytes), opts...)
+	client := http.NewClient()
+	ctx := context.Background()
 
 	// The list of files we fetch / cache.
-	files := []string{URL1, URL2, URL3}
+	urls := []string{URL1, URL2, URL3}
 	fetched_file_hash := [3]string{"", "", ""}
 	cached_file_hash := [3]string{"", "", ""}
 
-	for f := 0; f < len(files); f++ { // Fetch each file
+	for f := 0; f < len(urls); f++ { // Fetch each file
 
-		URL := files[f]
+		URL := urls[f]
 
 		// Do this two times, first to get the file, second to see if we can use
 		// our cached version.
 		for i := 0; i < 2; i++ { // Fetch each two times, to test cache.
 
-			// Headers we send with our request.
-			req_headers := make(map[string]string)
-
-			// First check our cache for the headers from the URL,
-			// if we find them, add headers to our request
-			cached_etag := cache.GetHeader(URL, ETAG)
-			cached_last_mod := cache.GetHeader(URL, LAST_MODIFIED)
-			if 0 < len(cached_etag) && 0 < len(cached_last_mod) {
-				req_headers[IF_NONE_MATCH] = cached_etag
-				req_headers[IF_MODIFIED_SINCE] = cached_last_mod
-			}
-
-			// Get the file
-			status, headers, file_bytes := http.HTTP_GET(HOST, URL, req_headers)
-
-			// Get and cache the headers we care about from the response
-			etag := headers[ETAG]
-			last_mod := headers[LAST_MODIFIED]
-			if 0 < len(etag) {
-				cache.SetHeader(URL, ETAG, etag[0])
-			}
-			if 0 < len(last_mod) {
-				cache.SetHeader(URL, LAST_MODIFIED, last_mod[0])
+			resp, status, err := client.Fetch(ctx, HOST, URL, responses.Shard(HOST+URL))
+			if nil != err {
+				fmt.Println("Error:", err)
+				return
 			}
 
-			if 200 == status {
-				// We got the file!
-				cache.SetFile(URL, file_bytes)
+			bytes := md5.Sum(resp.Body)
+			switch status {
+			case http.Fetched:
 				fmt.Println("Fetched and cached:", URL)
-				bytes := md5.Sum(file_bytes)
 				fetched_file_hash[f] = string(bytes[:])
-				if *pverbose {
-					cache.Print()
-				}
-
-			} else if 304 == status {
-				// Server says use our cached version
-				file_bytes = cache.GetFile(URL)
-				fmt.Println("Cache hit for:", URL)
-				bytes := md5.Sum(file_bytes)
+			case http.Fresh, http.Revalidated:
+				fmt.Println("Cache hit for:", URL, "("+status.String()+")")
 				cached_file_hash[f] = string(bytes[:])
-				if *pverbose {
-					cache.Print()
-				}
-
-			} else {
-				fmt.Println("Error: unhandled status code:", status)
+			default:
+				fmt.Println("Error: unhandled status:", status)
 				return
 			}
+			if *pverbose {
+				responses.Shard(HOST + URL).Print()
+			}
 		}
 		fmt.Println()
 	}
 
 	// Validate
-	for f := 0; f < len(files); f++ {
+	for f := 0; f < len(urls); f++ {
 		if fetched_file_hash[f] != cached_file_hash[f] {
 			fmt.Println("Error: file hashes do not match")
 		}
 	}
 	if *pverbose {
-		cache.Print()
+		fmt.Printf("Per-shard stats: %+v\n", responses.Stats())
 	}
 }
@@ -1,227 +1,378 @@
-// Package cache implements a cache on top of a map (dict/hashtable)
-// A Least Recently Used (LRU) list of keys is maintained, so that when
-// the cache is about to exceed its maximum capacity, it can evict the
-// LRU item and recover its space.
+// Package cache implements a generic, thread-safe cache on top of a map
+// (dict/hashtable). Eviction order is delegated to a pluggable Policy
+// (LRU by default; see policy.go), so when the cache is about to exceed
+// its maximum byte capacity it can ask the policy which key to evict and
+// recover that item's space.
 package cache
 
 import (
-	"container/list" // used to implement the LRU list, O(1)
 	"fmt"
+	"sync"
 )
 
-// A single item in the cache.
-type cache_item struct {
-	headers    map[string]string // header map of key: value
-	file_bytes []byte            // file bytes
-	item_size  int               // memory used by this item
+// valueEntry is the value half of a cache entry; the key half lives
+// inside whatever Policy is configured, which Cache never inspects.
+type valueEntry[V any] struct {
+	value V
+	size  int // bytes accounted for this entry, set via AddWithSize
 }
 
-// Members of the cache 'class'.
-type cache struct {
-	verbose       bool
-	keys          map[string]cache_item // map of URL: item
-	max_bytes     int
-	current_bytes int
-	LRU           *list.List // Least Recently Used list of keys
+// Cache is a thread-safe cache bounded by a maximum number of bytes, whose
+// eviction order is determined by a Policy. Use New to construct one.
+type Cache[K comparable, V any] struct {
+	mu           sync.RWMutex
+	maxBytes     int
+	currentBytes int
+	policy       Policy[K]
+	values       map[K]*valueEntry[V]
+	onEvict      func(K, V)
+	verbose      bool
+	disk         *diskStore[K, V]
+	diskDir      string // set by WithDiskStore; NewSharded rewrites this per shard
+	diskMaxBytes int64
+	hits         uint64
+	misses       uint64
+	evictions    uint64
 }
 
-// Construct a new cache and return it.
-func New(max_bytes int, verbose *bool) cache {
-	keys := make(map[string]cache_item)
-	LRU := list.New()
-	c := cache{*verbose, keys, max_bytes, 0, LRU}
-	return c
+// Stats is a snapshot of a Cache's activity counters, suitable for
+// logging or JSON dumping (see ShardedCache.Stats).
+type Stats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Len          int    `json:"len"`
+	CurrentBytes int    `json:"current_bytes"`
+	MaxBytes     int    `json:"max_bytes"`
 }
 
-// Return the number of bytes used by items in the cache.
-func (c *cache) UsedBytes() int {
-	return c.current_bytes
+// Option configures a Cache at construction time.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithOnEvict registers a callback invoked with the key and value of every
+// item evicted to make room for a new one (including via Remove or Purge).
+func WithOnEvict[K comparable, V any](fn func(K, V)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvict = fn
+	}
 }
 
-// Print the cache contents.
-func (c *cache) Print() {
-	multiple := "s"
-	if 1 == c.LRU.Len() {
-		multiple = ""
+// WithVerbose enables logging of cache activity (inserts, evictions) to
+// stdout, matching the old cache's -verbose behavior.
+func WithVerbose[K comparable, V any](verbose bool) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.verbose = verbose
 	}
-	fmt.Printf("%d Cache Item%s:\n", c.LRU.Len(), multiple)
-	for key, item := range c.keys {
-		fmt.Printf("  %s\n", key)
-		fmt.Println("    headers:", item.headers)
-		fmt.Println("    file len:", len(item.file_bytes))
-	}
-	fmt.Println("LRU list (last key is LRU):")
-	for e := c.LRU.Front(); e != nil; e = e.Next() {
-		fmt.Println("  ", e.Value)
-	}
-	fmt.Println("Max cache size:", c.max_bytes, "bytes")
-	fmt.Println("  Current size:", c.current_bytes, "bytes")
-	fmt.Println("        Unused:", c.max_bytes-c.current_bytes, "bytes")
-}
-
-// Set a header into the cache
-func (c *cache) SetHeader(key string, subkey string, value string) {
-	// Do we need to make room?
-	if !c.checkSizeAndEvict(len(value)) {
-		fmt.Println("Error: no space to store", key, subkey)
-		return
-	}
-	if item, found := c.keys[key]; found { // key exists, so add subkey
-		// does this subkey already exist?
-		if v, ok := item.headers[subkey]; ok {
-			// yes, so recover its space
-			item.item_size -= len(v)
-			c.current_bytes -= len(v)
-		}
-		item.headers[subkey] = value
-		item.item_size += len(value)
-		if c.verbose {
-			fmt.Println(">", key, subkey, "value size:", len(value),
-				"size before:", item.item_size-len(value),
-				"size after:", item.item_size)
-		}
-	} else { // key not found, so add it
-		headers := make(map[string]string)
-		headers[subkey] = value
-		item := cache_item{headers: headers, item_size: len(value)}
-		c.keys[key] = item
-		if c.verbose {
-			fmt.Println(">", key, subkey, "value size:", len(value))
+}
+
+// WithPolicy selects the eviction Policy the cache uses to decide which
+// key to evict. The default, if this option isn't given, is LRU.
+func WithPolicy[K comparable, V any](policy Policy[K]) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.policy = policy
+	}
+}
+
+// WithDiskStore gives the cache a second, on-disk tier rooted at dir,
+// bounded by maxDiskBytes. Instead of being dropped, an item evicted from
+// memory is demoted to this tier; Get promotes a disk hit back into
+// memory. A manifest file under dir lets the tier survive process
+// restarts, so values - including small ones like cached HTTP headers -
+// are still there to serve 304 revalidation on the next run.
+//
+// The store itself isn't opened until New returns, so NewSharded can
+// rewrite dir to a per-shard subdirectory first - shards sharing one
+// manifest would clobber each other's entries.
+func WithDiskStore[K comparable, V any](dir string, maxDiskBytes int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.diskDir = dir
+		c.diskMaxBytes = maxDiskBytes
+	}
+}
+
+// New constructs a Cache with the given maximum byte capacity. Options can
+// be used to register an eviction callback, enable verbose logging, or
+// select a non-default eviction Policy.
+func New[K comparable, V any](maxBytes int, opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		maxBytes: maxBytes,
+		values:   make(map[K]*valueEntry[V]),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if nil == c.policy {
+		c.policy = NewLRU[K]()
+	}
+	if "" != c.diskDir {
+		disk, err := newDiskStore[K, V](c.diskDir, c.diskMaxBytes)
+		if nil != err {
+			fmt.Println("cache: disk store at", c.diskDir, "disabled:", err)
+		} else {
+			c.disk = disk
 		}
 	}
-	c.current_bytes += len(value) // Total bytes in the cache
+	return c
+}
+
+// Add inserts or updates key with value, with no byte accounting. It is a
+// convenience for callers whose V isn't a []byte/header blob and who don't
+// need capacity enforcement by size; it is equivalent to
+// AddWithSize(key, value, 0).
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
+	return c.AddWithSize(key, value, 0)
+}
+
+// AddWithSize inserts or updates key with value, accounting size bytes
+// towards the cache's capacity. If size alone exceeds the cache's maximum,
+// the item is rejected and AddWithSize returns false. Otherwise, entries
+// are evicted - per the configured Policy - until there is room, and
+// evicted reports whether any eviction happened.
+func (c *Cache[K, V]) AddWithSize(key K, value V, size int) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if size > c.maxBytes {
+		fmt.Println("cache: rejecting", key, "-", size,
+			"bytes exceeds max capacity of", c.maxBytes, "bytes")
+		return false
+	}
+
+	if old, ok := c.values[key]; ok {
+		c.currentBytes -= old.size
+	}
+	c.values[key] = &valueEntry[V]{value: value, size: size}
+	c.currentBytes += size
+	c.policy.Insert(key, size)
+	evicted = c.evictUntilFits()
 	if c.verbose {
-		fmt.Println("> current_bytes:", c.current_bytes)
+		fmt.Println("cache: added", key, "size:", size)
 	}
-	// Put this key in the front (most recently used) spot in the LRU list.
-	c.addHeadToLRU(key)
+	return evicted
 }
 
-// Set a file into the cache
-func (c *cache) SetFile(key string, value []byte) {
-	// Do we need to make room?
-	if !c.checkSizeAndEvict(len(value)) {
-		fmt.Println("Error: no space to store file", key)
-		return
+// Get looks up key, notifying the Policy of the hit (e.g. moving it to the
+// front of an LRU list) if found. If key isn't in memory but a disk tier
+// is configured and has it, the value is promoted back into memory
+// before being returned.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ve, found := c.values[key]; found {
+		c.policy.Touch(key)
+		c.hits++
+		return ve.value, true
 	}
-	if item, found := c.keys[key]; found { // key exists, so set value
-		// does this file already exist?
-		if 0 < len(item.file_bytes) {
-			// yes, so recover its space
-			item.item_size -= len(item.file_bytes)
-			c.current_bytes -= len(item.file_bytes)
-		}
 
-		item.file_bytes = value
-		item.item_size += len(value)
-		c.keys[key] = item // replace item with updated one
-		if c.verbose {
-			fmt.Println(">", key, "file size:", len(value),
-				"size before:", item.item_size-len(value),
-				"size after:", item.item_size)
-		}
-	} else { // key not found, so add it
-		headers := make(map[string]string)
-		item := cache_item{headers: headers, file_bytes: value,
-			item_size: len(value)}
-		c.keys[key] = item
-		if c.verbose {
-			fmt.Println(">", key, "file size:", len(value))
-		}
+	if nil == c.disk {
+		c.misses++
+		return value, false
+	}
+	diskValue, size, found := c.disk.load(key)
+	if !found {
+		c.misses++
+		return value, false
 	}
-	c.current_bytes += len(value) // Total bytes in the cache
+	c.disk.remove(key)
+	c.values[key] = &valueEntry[V]{value: diskValue, size: size}
+	c.currentBytes += size
+	c.policy.Insert(key, size)
+	c.evictUntilFits()
+	c.hits++
 	if c.verbose {
-		fmt.Println("> current_bytes:", c.current_bytes)
+		fmt.Println("cache: promoted", key, "from disk")
 	}
-	// Put this key in the front (most recently used) spot in the LRU list.
-	c.addHeadToLRU(key)
+	return diskValue, true
 }
 
-// Get a header by key from the cache.
-func (c *cache) GetHeader(key string, subkey string) string {
-	c.addHeadToLRU(key) // Move this key to the front of the LRU list.
-	return c.keys[key].headers[subkey]
+// Peek returns key's value without notifying the Policy of an access.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ve, found := c.values[key]
+	if !found {
+		return value, false
+	}
+	return ve.value, true
 }
 
-// Get a file by key from the cache.
-func (c *cache) GetFile(key string) []byte {
-	c.addHeadToLRU(key) // Move this key to the front of the LRU list.
-	return c.keys[key].file_bytes
+// Contains reports whether key is in the cache, without notifying the
+// Policy of an access.
+func (c *Cache[K, V]) Contains(key K) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, found := c.values[key]
+	return found
 }
 
-// If the key is in the list, remove it.
-// This function is not exported, so like private, since it starts with a
-// lower case letter.
-func (c *cache) removeFromLRU(key string) {
-	// (The linear search below will be slow if many items in the list.)
-	for i := c.LRU.Front(); i != nil; i = i.Next() {
-		if key == i.Value {
-			c.LRU.Remove(i)
-			return
+// Remove deletes key from the cache (and its disk tier, if any), and
+// reports whether it was found in memory.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if nil != c.disk {
+		c.disk.remove(key)
+	}
+	ve, found := c.values[key]
+	if !found {
+		return false
+	}
+	c.removeKey(key, ve, false)
+	return true
+}
+
+// Purge clears the entire cache, including its disk tier, invoking the
+// eviction callback (if any) for every item removed from memory.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, ve := range c.values {
+		c.policy.Remove(key)
+		if nil != c.disk {
+			c.disk.remove(key)
+		}
+		if c.onEvict != nil {
+			c.onEvict(key, ve.value)
+		}
+	}
+	if nil != c.disk {
+		// Demoted keys are no longer in c.values, so clear them from the
+		// disk tier's own manifest.
+		c.disk.mu.Lock()
+		diskKeys := make([]K, 0, len(c.disk.manifest.Entries))
+		for key := range c.disk.manifest.Entries {
+			diskKeys = append(diskKeys, key)
+		}
+		c.disk.mu.Unlock()
+		for _, key := range diskKeys {
+			c.disk.remove(key)
 		}
 	}
+	c.values = make(map[K]*valueEntry[V])
+	c.currentBytes = 0
 }
 
-// Put this key at the head of the list (make it the Most recently used).
-func (c *cache) addHeadToLRU(key string) {
-	// If the key is already in the list in another position, remove it.
-	c.removeFromLRU(key)
-	// Put the key at the front of the list.
-	c.LRU.PushFront(key)
+// Len returns the number of items currently in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.values)
 }
 
-// Returns the key of the LRU item, or an empty string if the LRU list is empty.
-func (c *cache) getLRU() string {
-	if 0 == c.LRU.Len() {
-		return ""
+// UsedBytes returns the number of bytes currently accounted for by items
+// added via AddWithSize.
+func (c *Cache[K, V]) UsedBytes() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.currentBytes
+}
+
+// Stats returns a snapshot of the cache's hit/miss/eviction counters and
+// current size.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Stats{
+		Hits:         c.hits,
+		Misses:       c.misses,
+		Evictions:    c.evictions,
+		Len:          len(c.values),
+		CurrentBytes: c.currentBytes,
+		MaxBytes:     c.maxBytes,
 	}
-	i := c.LRU.Back() // last item in list, so LRU
-	ret := i.Value
-	c.LRU.Remove(i)     // remove the LRU item
-	return ret.(string) // convert List Element interface to string
 }
 
-// Will adding this value exceed our our capacity?
-// If so, evict as many LRU items as we need to, to make room.
-// Arguments: the size of the item to add.
-func (c *cache) checkSizeAndEvict(value_size int) bool {
-	if value_size > c.max_bytes {
-		fmt.Println("Error: trying to store", value_size, "bytes in a cache "+
-			" of maximum size", c.max_bytes, "bytes")
-		return false
+// Keys returns the cache's keys, in no particular order - the Policy in
+// use decides eviction order, but isn't required to expose it wholesale.
+func (c *Cache[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]K, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
 	}
+	return keys
+}
 
-	// Loop until we have freed as many LRU items as we need to,
-	// for space to store the new value.
-	for {
-		if c.current_bytes+value_size < c.max_bytes {
-			return true // There is enough space in cache for value.
-		}
+// Print dumps the cache contents, for debugging.
+func (c *Cache[K, V]) Print() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-		lru := c.getLRU() // Get and evict the LRU
-		if "" == lru {
-			return true // The list/cache is empty
-		}
+	multiple := "s"
+	if 1 == len(c.values) {
+		multiple = ""
+	}
+	fmt.Printf("%d Cache Item%s:\n", len(c.values), multiple)
+	for key, ve := range c.values {
+		fmt.Printf("  %v: %v (%d bytes)\n", key, ve.value, ve.size)
+	}
+	fmt.Println("Max cache size:", c.maxBytes, "bytes")
+	fmt.Println("  Current size:", c.currentBytes, "bytes")
+	fmt.Println("        Unused:", c.maxBytes-c.currentBytes, "bytes")
+}
 
-		// Recover the bytes used by this item
-		recovered_bytes := c.keys[lru].item_size
-		c.current_bytes -= recovered_bytes
-		if c.verbose {
-			fmt.Println(">> recovered:", recovered_bytes,
-				"current_bytes before:", c.current_bytes+recovered_bytes,
-				"after:", c.current_bytes)
+// evictUntilFits asks the Policy for victims until currentBytes fits
+// within maxBytes, invoking the eviction callback (if any) for each one
+// removed.
+func (c *Cache[K, V]) evictUntilFits() (evicted bool) {
+	for c.currentBytes > c.maxBytes {
+		key, ok := c.policy.Victim()
+		if !ok {
+			return evicted
 		}
-		if 0 > c.current_bytes {
-			c.current_bytes = 0
+		ve, ok := c.values[key]
+		if !ok {
+			// Policy and Cache disagree about what's cached; nothing to
+			// recover, but forget the stale key so we don't spin.
+			c.policy.Remove(key)
+			continue
 		}
+		c.removeKey(key, ve, true)
+		evicted = true
+	}
+	return evicted
+}
 
-		// Remove the LRU from the cache dict
-		delete(c.keys, lru) // delete is a built in, works on maps.
-
-		// Remove the LRU from the list
-		c.removeFromLRU(lru)
-		fmt.Println("Evicted", lru, "from the cache and recovered",
-			recovered_bytes, "bytes.")
+// removeKey removes key's value from memory, recovering its bytes. demote
+// is true when this is a real capacity-driven eviction (from
+// evictUntilFits), in which case the Policy is told via Evict - so 2Q/ARC
+// can update their ghost lists and adaptive targets - and, if a disk tier
+// is configured, the value is written there instead of being dropped
+// outright. demote is false for an explicit Remove/Purge, which isn't
+// evidence of eviction pressure, so the Policy is told via Remove instead.
+func (c *Cache[K, V]) removeKey(key K, ve *valueEntry[V], demote bool) {
+	delete(c.values, key)
+	if demote {
+		c.policy.Evict(key)
+	} else {
+		c.policy.Remove(key)
+	}
+	c.currentBytes -= ve.size
+	if 0 > c.currentBytes {
+		c.currentBytes = 0
+	}
+	if c.verbose {
+		fmt.Println("cache: evicted", key, "recovered", ve.size, "bytes")
+	}
+	if demote {
+		c.evictions++
+		if nil != c.disk {
+			if err := c.disk.store(key, ve.value, ve.size); nil != err && c.verbose {
+				fmt.Println("cache: failed to demote", key, "to disk:", err)
+			}
+		}
+	}
+	if c.onEvict != nil {
+		c.onEvict(key, ve.value)
 	}
-	return true
 }
@@ -3,72 +3,107 @@ package cache_test
 
 import (
 	"." // imports this current directory so we get the cache package
-	"bytes"
+	"sync"
 	"testing"
 )
 
 // Testing for this package
 func TestCache(t *testing.T) {
 
-	size := 40
-	verbose := true
-	cache := cache.New(size, &verbose)
-	if cache.UsedBytes() != 0 {
-		cache.Print()
-		t.Errorf("Expected 0, got %d", cache.UsedBytes())
+	size := 31
+	c := cache.New[string, string](size, cache.WithVerbose[string, string](true))
+	if c.UsedBytes() != 0 {
+		c.Print()
+		t.Errorf("Expected 0, got %d", c.UsedBytes())
 	}
 
 	key := "url1"
-	header := "header1"
 	value := "abcdefghij" // 10
-	cache.SetHeader(key, header, value)
-	if cache.UsedBytes() != 10 {
-		cache.Print()
-		t.Errorf("SetHeader 1, Expected 10, got %d", cache.UsedBytes())
+	c.AddWithSize(key, value, len(value))
+	if c.UsedBytes() != 10 {
+		c.Print()
+		t.Errorf("AddWithSize 1, Expected 10, got %d", c.UsedBytes())
 	}
 
-	if value != cache.GetHeader(key, header) {
-		cache.Print()
-		t.Errorf("GetHeader, Expected %s, got %s", value,
-			cache.GetHeader(key, header))
+	if got, ok := c.Get(key); !ok || got != value {
+		c.Print()
+		t.Errorf("Get, Expected %s, got %s", value, got)
 	}
 
-	// set same header again, should not use any more space
-	cache.SetHeader(key, header, value)
-	if cache.UsedBytes() != 10 {
-		cache.Print()
-		t.Errorf("SetHeader 2, Expected 10, got %d", cache.UsedBytes())
+	// re-add the same value, should not use any more space
+	c.AddWithSize(key, value, len(value))
+	if c.UsedBytes() != 10 {
+		c.Print()
+		t.Errorf("AddWithSize 2, Expected 10, got %d", c.UsedBytes())
 	}
 
-	file_bytes := []byte("0123456789") // 10
-	cache.SetFile(key, file_bytes)
-	if cache.UsedBytes() != 20 {
-		cache.Print()
-		t.Errorf("SetFile 1, Expected 20, got %d", cache.UsedBytes())
+	// fill the cache to see if it handles eviction
+	c.AddWithSize("url2", value, len(value))
+	if c.UsedBytes() != 20 {
+		c.Print()
+		t.Errorf("Expected 20, got %d", c.UsedBytes())
 	}
-
-	if !bytes.Equal(file_bytes, cache.GetFile(key)) {
-		cache.Print()
-		t.Errorf("GetFile, Expected %s, got %s", file_bytes,
-			cache.GetFile(key))
+	c.AddWithSize("url3", value, len(value))
+	if c.UsedBytes() != 30 {
+		c.Print()
+		t.Errorf("Expected 30, got %d", c.UsedBytes())
+	}
+	evicted := c.AddWithSize("url4", value, len(value))
+	if !evicted {
+		t.Errorf("Expected url4 insertion to evict the LRU item")
+	}
+	if c.UsedBytes() != 30 {
+		c.Print()
+		t.Errorf("Expected 30, got %d", c.UsedBytes())
 	}
+	if c.Contains("url1") {
+		t.Errorf("Expected url1 to have been evicted as the LRU item")
+	}
+}
+
+// TestCacheEvictionOrder verifies that the least recently used key, not
+// necessarily the oldest inserted key, is the one evicted.
+func TestCacheEvictionOrder(t *testing.T) {
+	var evictedKeys []string
+	c := cache.New[string, int](3,
+		cache.WithOnEvict[string, int](func(k string, v int) {
+			evictedKeys = append(evictedKeys, k)
+		}))
+
+	c.AddWithSize("a", 1, 1)
+	c.AddWithSize("b", 2, 1)
+	c.AddWithSize("c", 3, 1)
 
-	// set same file again, should not use any more space
-	cache.SetFile(key, file_bytes)
-	if cache.UsedBytes() != 20 {
-		cache.Print()
-		t.Errorf("SetFile 2, Expected 20, got %d", cache.UsedBytes())
+	// Touch "a" so "b" becomes the least recently used.
+	c.Get("a")
+
+	c.AddWithSize("d", 4, 1) // Should evict "b", not "a".
+
+	if len(evictedKeys) != 1 || evictedKeys[0] != "b" {
+		t.Errorf("Expected \"b\" to be evicted, got %v", evictedKeys)
 	}
+	if !c.Contains("a") {
+		t.Errorf("Expected \"a\" to survive the eviction")
+	}
+}
 
-	// fill the cache to see if it handles eviction
-	cache.SetHeader("url2", header, value)
-	if cache.UsedBytes() != 30 {
-		cache.Print()
-		t.Errorf("Expected 30, got %d", cache.UsedBytes())
+// TestCacheConcurrent exercises Add and Get from many goroutines at once,
+// to catch data races under `go test -race`.
+func TestCacheConcurrent(t *testing.T) {
+	c := cache.New[int, int](1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.AddWithSize(i, i, 1)
+			c.Get(i)
+		}(i)
 	}
-	cache.SetHeader("url3", header, value)
-	if cache.UsedBytes() != 20 {
-		cache.Print()
-		t.Errorf("Expected 20, got %d", cache.UsedBytes())
+	wg.Wait()
+
+	if c.Len() > 1000 {
+		t.Errorf("Expected at most 1000 items, got %d", c.Len())
 	}
 }
@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// diskManifestEntry records where a single key's value lives on disk.
+type diskManifestEntry struct {
+	Hash string // content-addressed blob filename: sha256(key) in hex
+	Size int64
+}
+
+// diskManifest is gob-encoded to disk so a restart can rebuild the index
+// without re-reading every blob. Order lists keys oldest-demoted-first,
+// so the disk tier can evict in the same spirit as the memory tier
+// without needing its own copy of whatever Policy the memory tier uses.
+type diskManifest[K comparable] struct {
+	Entries map[K]diskManifestEntry
+	Order   []K
+}
+
+// diskStore is the on-disk tier behind a Cache: values demoted out of
+// memory are gob-encoded into content-addressed files named after the
+// SHA-256 hash of their key, so blobs can be found again after a restart
+// without needing to recover the key from the filename.
+type diskStore[K comparable, V any] struct {
+	mu           sync.Mutex
+	dir          string
+	maxBytes     int64
+	usedBytes    int64
+	manifestPath string
+	manifest     diskManifest[K]
+}
+
+// newDiskStore opens (or creates) the on-disk tier rooted at dir, loading
+// any manifest left behind by a previous run.
+func newDiskStore[K comparable, V any](dir string, maxBytes int64) (*diskStore[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); nil != err {
+		return nil, err
+	}
+	d := &diskStore[K, V]{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		manifestPath: filepath.Join(dir, "manifest.gob"),
+		manifest:     diskManifest[K]{Entries: make(map[K]diskManifestEntry)},
+	}
+	if err := d.loadManifest(); nil != err {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *diskStore[K, V]) loadManifest() error {
+	f, err := os.Open(d.manifestPath)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return nil // first run: nothing to load yet
+		}
+		return err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&d.manifest); nil != err {
+		return err
+	}
+	if nil == d.manifest.Entries {
+		d.manifest.Entries = make(map[K]diskManifestEntry)
+	}
+	for _, entry := range d.manifest.Entries {
+		d.usedBytes += entry.Size
+	}
+	return nil
+}
+
+// saveManifest writes the manifest via a temp file + rename, so a crash
+// mid-write can't corrupt the copy a future restart would read.
+func (d *diskStore[K, V]) saveManifest() error {
+	tmp := d.manifestPath + ".tmp"
+	f, err := os.Create(tmp)
+	if nil != err {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(d.manifest); nil != err {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); nil != err {
+		return err
+	}
+	return os.Rename(tmp, d.manifestPath)
+}
+
+func (d *diskStore[K, V]) hashFor(key K) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v", key)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *diskStore[K, V]) pathFor(hash string) string {
+	return filepath.Join(d.dir, hash)
+}
+
+// store gob-encodes value into key's content-addressed blob file and
+// records it in the manifest, evicting the oldest disk entries first if
+// that's needed to stay within maxBytes. A value bigger than maxBytes on
+// its own is rejected outright, same as Cache.AddWithSize does for the
+// memory tier, rather than being written and then immediately evicted.
+func (d *diskStore[K, V]) store(key K, value V, size int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if int64(size) > d.maxBytes {
+		return fmt.Errorf("disk store: %d bytes exceeds max capacity of %d bytes", size, d.maxBytes)
+	}
+
+	hash := d.hashFor(key)
+	f, err := os.Create(d.pathFor(hash))
+	if nil != err {
+		return err
+	}
+	err = gob.NewEncoder(f).Encode(value)
+	closeErr := f.Close()
+	if nil != err {
+		return err
+	}
+	if nil != closeErr {
+		return closeErr
+	}
+
+	if old, exists := d.manifest.Entries[key]; exists {
+		d.usedBytes -= old.Size
+		d.removeFromOrder(key)
+	}
+	d.manifest.Entries[key] = diskManifestEntry{Hash: hash, Size: int64(size)}
+	d.manifest.Order = append(d.manifest.Order, key)
+	d.usedBytes += int64(size)
+
+	for d.usedBytes > d.maxBytes && 0 < len(d.manifest.Order) {
+		d.removeLocked(d.manifest.Order[0])
+	}
+
+	return d.saveManifest()
+}
+
+// load reads key's value back from disk, if present.
+func (d *diskStore[K, V]) load(key K) (value V, size int, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, found := d.manifest.Entries[key]
+	if !found {
+		return value, 0, false
+	}
+	f, err := os.Open(d.pathFor(entry.Hash))
+	if nil != err {
+		return value, 0, false
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&value); nil != err {
+		return value, 0, false
+	}
+	return value, int(entry.Size), true
+}
+
+// remove deletes key from the disk tier, if present.
+func (d *diskStore[K, V]) remove(key K) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(key)
+	d.saveManifest()
+}
+
+func (d *diskStore[K, V]) removeLocked(key K) {
+	entry, found := d.manifest.Entries[key]
+	if !found {
+		return
+	}
+	os.Remove(d.pathFor(entry.Hash))
+	delete(d.manifest.Entries, key)
+	d.removeFromOrder(key)
+	d.usedBytes -= entry.Size
+	if 0 > d.usedBytes {
+		d.usedBytes = 0
+	}
+}
+
+func (d *diskStore[K, V]) removeFromOrder(key K) {
+	for i, k := range d.manifest.Order {
+		if k == key {
+			d.manifest.Order = append(d.manifest.Order[:i], d.manifest.Order[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Test Package cache
+package cache_test
+
+import (
+	"." // imports this current directory so we get the cache package
+	"bytes"
+	"testing"
+)
+
+// TestDiskDemoteAndPromote checks that an item evicted from a tiny
+// in-memory cache survives on disk and comes back via Get.
+func TestDiskDemoteAndPromote(t *testing.T) {
+	dir := t.TempDir()
+
+	c := cache.New[string, []byte](10, cache.WithDiskStore[string, []byte](dir, 1<<20))
+
+	c.AddWithSize("a", []byte("aaaaa"), 5) // 5 bytes
+	c.AddWithSize("b", []byte("bbbbb"), 5) // 5 bytes, cache now full
+	c.AddWithSize("c", []byte("ccccc"), 5) // evicts "a" (LRU) to disk
+
+	if c.Contains("a") {
+		t.Errorf("Expected \"a\" to have left memory")
+	}
+
+	got, ok := c.Get("a")
+	if !ok {
+		t.Fatalf("Expected \"a\" to be promoted back from disk")
+	}
+	if !bytes.Equal(got, []byte("aaaaa")) {
+		t.Errorf("Expected %q, got %q", "aaaaa", got)
+	}
+	if !c.Contains("a") {
+		t.Errorf("Expected \"a\" to be back in memory after Get")
+	}
+}
+
+// TestDiskRejectsOversizedValue checks that a value too big for the disk
+// tier's maxDiskBytes is rejected outright, rather than being written to a
+// blob file and added to the manifest first and only then evicted to stay
+// within budget - which, being the only/oldest entry in a degenerate
+// case, can wipe out unrelated entries that were legitimately on disk
+// already.
+func TestDiskRejectsOversizedValue(t *testing.T) {
+	dir := t.TempDir()
+
+	// Pre-populate the disk tier with a small, legitimate entry.
+	c1 := cache.New[string, []byte](100, cache.WithDiskStore[string, []byte](dir, 10))
+	c1.AddWithSize("z", []byte("zzzzz"), 5)
+	c1.AddWithSize("filler", make([]byte, 96), 96) // evicts "z" (LRU) to disk; 5 <= maxDiskBytes(10)
+
+	// A fresh Cache over the same disk dir (simulating a restart) whose
+	// memory capacity exactly matches an oversized value, so a second,
+	// tiny insert immediately evicts it as the LRU victim.
+	c2 := cache.New[string, []byte](50, cache.WithDiskStore[string, []byte](dir, 10))
+	c2.AddWithSize("a", make([]byte, 50), 50) // 50 bytes, bigger than maxDiskBytes(10)
+	c2.AddWithSize("b", []byte("b"), 1)       // evicts "a" (LRU) to disk, which must reject it
+
+	if _, ok := c2.Get("a"); ok {
+		t.Errorf("Expected \"a\" (50 bytes) to be rejected by the disk tier (maxDiskBytes 10)")
+	}
+
+	// The rejection must not have clobbered "z", an unrelated entry that
+	// was already on disk.
+	c3 := cache.New[string, []byte](100, cache.WithDiskStore[string, []byte](dir, 10))
+	got, ok := c3.Get("z")
+	if !ok {
+		t.Fatalf("Expected \"z\" to still be recoverable; an oversized insert must not evict unrelated entries")
+	}
+	if !bytes.Equal(got, []byte("zzzzz")) {
+		t.Errorf("Expected %q, got %q", "zzzzz", got)
+	}
+}
+
+// TestDiskManifestSurvivesRestart checks that a new Cache pointed at the
+// same directory can see a value demoted by a previous one, simulating a
+// process restart.
+func TestDiskManifestSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	c1 := cache.New[string, []byte](5, cache.WithDiskStore[string, []byte](dir, 1<<20))
+	c1.AddWithSize("a", []byte("aaaaa"), 5)
+	c1.AddWithSize("b", []byte("bbbbb"), 5) // evicts "a" to disk
+
+	// Simulate a restart: a brand new Cache, same disk directory.
+	c2 := cache.New[string, []byte](5, cache.WithDiskStore[string, []byte](dir, 1<<20))
+	got, ok := c2.Get("a")
+	if !ok {
+		t.Fatalf("Expected \"a\" to be recovered from the manifest after a restart")
+	}
+	if !bytes.Equal(got, []byte("aaaaa")) {
+		t.Errorf("Expected %q, got %q", "aaaaa", got)
+	}
+}
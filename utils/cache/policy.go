@@ -0,0 +1,51 @@
+package cache
+
+import "fmt"
+
+// Policy decides which key a Cache should evict next. A Cache delegates
+// all key ordering/bookkeeping to its Policy and keeps the key->value
+// map and byte accounting itself; Policy implementations only ever see
+// keys (and, for Insert, the size passed to AddWithSize), never values.
+//
+// Implementations are not safe for concurrent use on their own; Cache
+// serializes access to its Policy with its own mutex.
+type Policy[K comparable] interface {
+	// Touch records an access (cache hit) to key.
+	Touch(key K)
+	// Insert records that key now holds a value of size bytes, whether
+	// key is new to the policy or already tracked.
+	Insert(key K, size int)
+	// Victim returns the key the policy recommends evicting next, or
+	// the zero value and false if the policy has nothing to evict.
+	Victim() (key K, ok bool)
+	// Evict forgets key because Cache actually evicted it for space, per
+	// a prior Victim call. Policies whose algorithm reacts to eviction
+	// pressure (e.g. 2Q's and ARC's ghost lists) update that state here.
+	Evict(key K)
+	// Remove forgets key because a caller explicitly removed it (Cache.Remove
+	// or Purge), which - unlike Evict - isn't evidence of eviction pressure
+	// and shouldn't be treated as such.
+	Remove(key K)
+}
+
+// NewPolicyByName constructs the eviction Policy identified by name: one
+// of "lru" (the default), "lfu", "2q", "arc", or "sieve". capacity is an
+// approximate number of items the policy should target; it is only
+// consulted by policies whose algorithm is capacity-aware (2q, arc) and
+// is otherwise ignored.
+func NewPolicyByName[K comparable](name string, capacity int) (Policy[K], error) {
+	switch name {
+	case "", "lru":
+		return NewLRU[K](), nil
+	case "lfu":
+		return NewLFU[K](), nil
+	case "2q":
+		return NewTwoQueue[K](capacity), nil
+	case "arc":
+		return NewARC[K](capacity), nil
+	case "sieve":
+		return NewSieve[K](), nil
+	default:
+		return nil, fmt.Errorf("cache: unknown eviction policy %q", name)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import "container/list"
+
+// TwoQueue implements the 2Q replacement policy (Johnson & Shasha, 1994):
+// a key seen for the first time lands in a small FIFO of "recent" entries
+// (one-hit wonders), and is only promoted to the "frequent" LRU once it is
+// referenced a second time. Keys evicted out of the recent FIFO leave
+// behind a "ghost" entry (key only, no value); a reference to a ghost
+// promotes the key straight into the frequent list, since the ghost hit
+// proves it wasn't really a one-hit wonder.
+type TwoQueue[K comparable] struct {
+	recent     *list.List // FIFO: one-hit wonders, oldest at the back
+	frequent   *list.List // LRU: repeat hits and promoted ghosts
+	ghost      *list.List // FIFO of evicted recent keys, oldest at the back
+	elems      map[K]*list.Element
+	inGhost    map[K]*list.Element
+	inFrequent map[K]bool // true if elems[key] lives in `frequent`, false if in `recent`
+	maxGhost   int
+}
+
+// NewTwoQueue constructs a 2Q policy. ghostSize bounds how many evicted
+// keys are remembered in the ghost list; the cache's approximate item
+// capacity is a reasonable choice.
+func NewTwoQueue[K comparable](ghostSize int) *TwoQueue[K] {
+	if ghostSize <= 0 {
+		ghostSize = 1
+	}
+	return &TwoQueue[K]{
+		recent:     list.New(),
+		frequent:   list.New(),
+		ghost:      list.New(),
+		elems:      make(map[K]*list.Element),
+		inGhost:    make(map[K]*list.Element),
+		inFrequent: make(map[K]bool),
+		maxGhost:   ghostSize,
+	}
+}
+
+func (p *TwoQueue[K]) Touch(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	if p.inFrequent[key] {
+		p.frequent.MoveToFront(el)
+		return
+	}
+	// A second reference while still in `recent` proves this isn't a
+	// one-hit wonder: promote it to the frequent LRU.
+	p.recent.Remove(el)
+	p.elems[key] = p.frequent.PushFront(key)
+	p.inFrequent[key] = true
+}
+
+func (p *TwoQueue[K]) Insert(key K, size int) {
+	if _, tracked := p.elems[key]; tracked {
+		p.Touch(key)
+		return
+	}
+	if gel, ok := p.inGhost[key]; ok {
+		// A rehit of a recently evicted key: promote straight into the
+		// frequent list rather than making it serve another "recent" turn.
+		p.ghost.Remove(gel)
+		delete(p.inGhost, key)
+		p.elems[key] = p.frequent.PushFront(key)
+		p.inFrequent[key] = true
+		return
+	}
+	p.elems[key] = p.recent.PushFront(key)
+	p.inFrequent[key] = false
+}
+
+func (p *TwoQueue[K]) Victim() (key K, ok bool) {
+	if 0 < p.recent.Len() {
+		return p.recent.Back().Value.(K), true
+	}
+	if 0 < p.frequent.Len() {
+		return p.frequent.Back().Value.(K), true
+	}
+	return key, false
+}
+
+// Evict is how 2Q learns a key actually left the cache for space: if it
+// was still a one-hit wonder in `recent`, a ghost is left behind so a
+// rehit can be recognized later.
+func (p *TwoQueue[K]) Evict(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	wasFrequent := p.inFrequent[key]
+	if wasFrequent {
+		p.frequent.Remove(el)
+	} else {
+		p.recent.Remove(el)
+		p.inGhost[key] = p.ghost.PushFront(key)
+		for p.maxGhost < p.ghost.Len() {
+			back := p.ghost.Back()
+			p.ghost.Remove(back)
+			delete(p.inGhost, back.Value.(K))
+		}
+	}
+	delete(p.elems, key)
+	delete(p.inFrequent, key)
+}
+
+// Remove forgets key outright - an explicit Cache.Remove or Purge, not a
+// real eviction - so unlike Evict it leaves no ghost behind.
+func (p *TwoQueue[K]) Remove(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	if p.inFrequent[key] {
+		p.frequent.Remove(el)
+	} else {
+		p.recent.Remove(el)
+	}
+	delete(p.elems, key)
+	delete(p.inFrequent, key)
+}
@@ -0,0 +1,204 @@
+package cache
+
+import "container/list"
+
+// location identifies which of ARC's four internal lists a key lives in.
+type location int
+
+const (
+	locT1 location = iota // recency: referenced once, recently
+	locT2                 // frequency: referenced at least twice
+	locB1                 // ghost of a key evicted out of T1
+	locB2                 // ghost of a key evicted out of T2
+)
+
+// ARC implements Adaptive Replacement Cache (Megiddo & Modha, 2003): T1/T2
+// hold the actual cached keys (recency- and frequency-favored
+// respectively) and B1/B2 are ghost lists of keys recently evicted from
+// T1/T2. A hit in B1 means the recency list is too small, so the target
+// split p grows to favor T1; a hit in B2 means the opposite, so p shrinks.
+//
+// ARC's textbook algorithm decides, at the moment a key is inserted,
+// which real list to evict from to make room (its "REPLACE" step). This
+// Policy interface only learns about an eviction after the fact (via
+// Evict, once Cache has actually dropped the value), so REPLACE here
+// only records a preference; Victim honors it on the next eviction.
+type ARC[K comparable] struct {
+	c, p           int // target cache capacity, and target size of T1
+	t1, t2, b1, b2 *list.List
+	where          map[K]location
+	elems          map[K]*list.Element
+	preferT1       bool
+}
+
+// NewARC constructs an ARC policy targeting capacity keys resident in the
+// cache at once (T1+T2); the ghost lists B1/B2 are bounded to the same
+// size.
+func NewARC[K comparable](capacity int) *ARC[K] {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &ARC[K]{
+		c:     capacity,
+		t1:    list.New(),
+		t2:    list.New(),
+		b1:    list.New(),
+		b2:    list.New(),
+		where: make(map[K]location),
+		elems: make(map[K]*list.Element),
+	}
+}
+
+func (p *ARC[K]) Touch(key K)            { p.access(key) }
+func (p *ARC[K]) Insert(key K, size int) { p.access(key) }
+
+// access implements ARC's four reference cases (I-IV from the paper) for
+// a touch or insert of key, uniformly: ARC treats every reference the
+// same whether it's a read (Touch) or a write (Insert).
+func (p *ARC[K]) access(key K) {
+	loc, tracked := p.where[key]
+	if !tracked {
+		loc = -1 // neither resident nor a ghost: Case IV below
+	}
+	switch loc {
+	case locT1, locT2:
+		// Case I: hit on a resident key. A second-or-later reference
+		// promotes it to the frequency list.
+		if el, ok := p.elems[key]; ok {
+			p.t1.Remove(el) // no-op if el actually lives in t2
+			p.t2.Remove(el)
+		}
+		p.elems[key] = p.t2.PushFront(key)
+		p.where[key] = locT2
+
+	case locB1:
+		// Case II: hit on a recency ghost. Grow the recency target.
+		delta := 1
+		if p.b1.Len() > 0 && p.b2.Len() > p.b1.Len() {
+			delta = p.b2.Len() / p.b1.Len()
+		}
+		p.p = min(p.c, p.p+delta)
+		p.preferT1 = p.t1.Len() > 0 && (p.t1.Len() > p.p || p.t2.Len() == 0)
+		p.forget(key, p.b1)
+		p.elems[key] = p.t2.PushFront(key)
+		p.where[key] = locT2
+
+	case locB2:
+		// Case III: hit on a frequency ghost. Grow the frequency target
+		// (shrink p, the recency target).
+		delta := 1
+		if p.b2.Len() > 0 && p.b1.Len() > p.b2.Len() {
+			delta = p.b1.Len() / p.b2.Len()
+		}
+		p.p = max(0, p.p-delta)
+		p.preferT1 = p.t1.Len() > 0 && p.t1.Len() > p.p
+		p.forget(key, p.b2)
+		p.elems[key] = p.t2.PushFront(key)
+		p.where[key] = locT2
+
+	default:
+		// Case IV: key never seen, or its ghost has already aged out.
+		p.preferT1 = p.t1.Len() > 0 && p.t1.Len() >= max(1, p.p)
+		p.elems[key] = p.t1.PushFront(key)
+		p.where[key] = locT1
+	}
+}
+
+func (p *ARC[K]) forget(key K, from *list.List) {
+	if el, ok := p.elems[key]; ok {
+		from.Remove(el)
+	}
+	delete(p.elems, key)
+	delete(p.where, key)
+}
+
+// Victim recommends T1's LRU key when REPLACE last preferred the recency
+// list (and it's non-empty), otherwise T2's LRU key, falling back to
+// whichever of T1/T2 is non-empty.
+func (p *ARC[K]) Victim() (key K, ok bool) {
+	if p.preferT1 && p.t1.Len() > 0 {
+		return p.t1.Back().Value.(K), true
+	}
+	if p.t2.Len() > 0 {
+		return p.t2.Back().Value.(K), true
+	}
+	if p.t1.Len() > 0 {
+		return p.t1.Back().Value.(K), true
+	}
+	return key, false
+}
+
+// Evict is how ARC learns a key actually left the cache for space (as
+// opposed to merely being referenced): it demotes the key from T1/T2 into
+// the matching ghost list B1/B2, trimming the ghost lists to stay bounded.
+func (p *ARC[K]) Evict(key K) {
+	loc, ok := p.where[key]
+	if !ok {
+		return
+	}
+	el := p.elems[key]
+	switch loc {
+	case locT1:
+		p.t1.Remove(el)
+		delete(p.elems, key)
+		p.where[key] = locB1
+		p.elems[key] = p.b1.PushFront(key)
+		p.trim(p.b1, key)
+	case locT2:
+		p.t2.Remove(el)
+		delete(p.elems, key)
+		p.where[key] = locB2
+		p.elems[key] = p.b2.PushFront(key)
+		p.trim(p.b2, key)
+	case locB1:
+		p.b1.Remove(el)
+		delete(p.elems, key)
+		delete(p.where, key)
+	case locB2:
+		p.b2.Remove(el)
+		delete(p.elems, key)
+		delete(p.where, key)
+	}
+}
+
+// listFor returns the list backing loc, for use by Remove.
+func (p *ARC[K]) listFor(loc location) *list.List {
+	switch loc {
+	case locT1:
+		return p.t1
+	case locT2:
+		return p.t2
+	case locB1:
+		return p.b1
+	case locB2:
+		return p.b2
+	}
+	return nil
+}
+
+// Remove forgets key outright - an explicit Cache.Remove or Purge, not a
+// real eviction - so unlike Evict it doesn't demote the key into a ghost
+// list or touch the adaptive target p.
+func (p *ARC[K]) Remove(key K) {
+	loc, ok := p.where[key]
+	if !ok {
+		return
+	}
+	p.forget(key, p.listFor(loc))
+}
+
+// trim drops the oldest ghost from b once it exceeds capacity, so B1/B2
+// don't grow without bound. justAdded is exempt so a single-item list
+// never evicts the key it just received.
+func (p *ARC[K]) trim(b *list.List, justAdded K) {
+	for b.Len() > p.c {
+		back := b.Back()
+		key := back.Value.(K)
+		if key == justAdded && b.Len() <= p.c+1 {
+			break
+		}
+		b.Remove(back)
+		delete(p.elems, key)
+		delete(p.where, key)
+	}
+}
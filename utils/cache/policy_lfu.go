@@ -0,0 +1,90 @@
+package cache
+
+import "container/list"
+
+// LFU is a Least Frequently Used policy implemented with the classic
+// frequency-bucketed doubly-linked-list structure: each distinct access
+// frequency has its own list, and touching a key moves it to the front of
+// the next-higher frequency's list. The victim is always the back (least
+// recently touched within its frequency) of the lowest non-empty
+// frequency list, so ties between equally-infrequent keys break LRU-style.
+type LFU[K comparable] struct {
+	freq    map[K]int
+	elems   map[K]*list.Element
+	buckets map[int]*list.List
+	minFreq int
+}
+
+// NewLFU constructs an empty LFU policy.
+func NewLFU[K comparable]() *LFU[K] {
+	return &LFU[K]{
+		freq:    make(map[K]int),
+		elems:   make(map[K]*list.Element),
+		buckets: make(map[int]*list.List),
+	}
+}
+
+func (p *LFU[K]) bucket(f int) *list.List {
+	b, ok := p.buckets[f]
+	if !ok {
+		b = list.New()
+		p.buckets[f] = b
+	}
+	return b
+}
+
+// bump moves key from its current frequency bucket to the next one up,
+// creating the key's first bucket (frequency 1) if it isn't tracked yet.
+func (p *LFU[K]) bump(key K) {
+	f, tracked := p.freq[key]
+	if tracked {
+		p.bucket(f).Remove(p.elems[key])
+	}
+	f++
+	p.freq[key] = f
+	p.elems[key] = p.bucket(f).PushFront(key)
+	if !tracked {
+		// A freshly-tracked key is the least frequent in the cache.
+		p.minFreq = 1
+	}
+}
+
+func (p *LFU[K]) Touch(key K) {
+	if _, ok := p.freq[key]; ok {
+		p.bump(key)
+	}
+}
+
+func (p *LFU[K]) Insert(key K, size int) {
+	p.bump(key)
+}
+
+func (p *LFU[K]) Victim() (key K, ok bool) {
+	if 0 == len(p.elems) {
+		return key, false
+	}
+	// minFreq may lag behind reality once its bucket empties out from
+	// under it (e.g. after Remove); climb until we find a non-empty one.
+	for f := p.minFreq; ; f++ {
+		if b, found := p.buckets[f]; found && 0 < b.Len() {
+			p.minFreq = f
+			return b.Back().Value.(K), true
+		}
+	}
+}
+
+func (p *LFU[K]) Remove(key K) {
+	f, ok := p.freq[key]
+	if !ok {
+		return
+	}
+	p.bucket(f).Remove(p.elems[key])
+	delete(p.freq, key)
+	delete(p.elems, key)
+}
+
+// Evict is identical to Remove: frequency buckets don't track eviction
+// pressure separately from an explicit removal.
+func (p *LFU[K]) Evict(key K) {
+	p.Remove(key)
+}
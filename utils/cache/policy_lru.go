@@ -0,0 +1,54 @@
+package cache
+
+import "container/list"
+
+// LRU is the classic Least Recently Used policy: the victim is always the
+// least recently touched/inserted key. Each key's *list.Element is held
+// directly in a map, so Touch, Insert and Remove are all O(1).
+type LRU[K comparable] struct {
+	ll    *list.List
+	elems map[K]*list.Element
+}
+
+// NewLRU constructs an empty LRU policy.
+func NewLRU[K comparable]() *LRU[K] {
+	return &LRU[K]{
+		ll:    list.New(),
+		elems: make(map[K]*list.Element),
+	}
+}
+
+func (p *LRU[K]) Touch(key K) {
+	if el, ok := p.elems[key]; ok {
+		p.ll.MoveToFront(el)
+	}
+}
+
+func (p *LRU[K]) Insert(key K, size int) {
+	if el, ok := p.elems[key]; ok {
+		p.ll.MoveToFront(el)
+		return
+	}
+	p.elems[key] = p.ll.PushFront(key)
+}
+
+func (p *LRU[K]) Victim() (key K, ok bool) {
+	e := p.ll.Back()
+	if nil == e {
+		return key, false
+	}
+	return e.Value.(K), true
+}
+
+func (p *LRU[K]) Remove(key K) {
+	if el, ok := p.elems[key]; ok {
+		p.ll.Remove(el)
+		delete(p.elems, key)
+	}
+}
+
+// Evict is identical to Remove: plain LRU keeps no ghost state that would
+// need to distinguish real eviction from an explicit removal.
+func (p *LRU[K]) Evict(key K) {
+	p.Remove(key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import "container/list"
+
+// Sieve implements the SIEVE eviction algorithm: a single FIFO queue with
+// a "visited" bit per entry and a moving hand. New keys are inserted at
+// the head; the hand sweeps from the tail toward the head looking for an
+// unvisited entry, clearing the visited bit of everything it passes over
+// (giving recently-touched entries a second chance) and evicting the
+// first unvisited entry it finds.
+type Sieve[K comparable] struct {
+	ll      *list.List
+	elems   map[K]*list.Element
+	visited map[K]bool
+	hand    *list.Element // where the next sweep resumes; nil means "start at the tail"
+}
+
+// NewSieve constructs an empty SIEVE policy.
+func NewSieve[K comparable]() *Sieve[K] {
+	return &Sieve[K]{
+		ll:      list.New(),
+		elems:   make(map[K]*list.Element),
+		visited: make(map[K]bool),
+	}
+}
+
+func (p *Sieve[K]) Touch(key K) {
+	if _, ok := p.elems[key]; ok {
+		p.visited[key] = true
+	}
+}
+
+func (p *Sieve[K]) Insert(key K, size int) {
+	if _, ok := p.elems[key]; ok {
+		p.visited[key] = true
+		return
+	}
+	p.elems[key] = p.ll.PushFront(key)
+	p.visited[key] = false
+}
+
+func (p *Sieve[K]) Victim() (key K, ok bool) {
+	if 0 == p.ll.Len() {
+		return key, false
+	}
+	e := p.hand
+	if nil == e {
+		e = p.ll.Back()
+	}
+	for {
+		k := e.Value.(K)
+		if !p.visited[k] {
+			p.hand = e.Prev()
+			return k, true
+		}
+		p.visited[k] = false
+		next := e.Prev()
+		if nil == next {
+			next = p.ll.Back()
+		}
+		e = next
+	}
+}
+
+func (p *Sieve[K]) Remove(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	if p.hand == el {
+		p.hand = el.Prev()
+	}
+	p.ll.Remove(el)
+	delete(p.elems, key)
+	delete(p.visited, key)
+}
+
+// Evict is identical to Remove: SIEVE's visited bits and hand don't track
+// eviction pressure separately from an explicit removal.
+func (p *Sieve[K]) Evict(key K) {
+	p.Remove(key)
+}
@@ -0,0 +1,102 @@
+// Test Package cache
+package cache_test
+
+import (
+	"." // imports this current directory so we get the cache package
+	"testing"
+)
+
+// TestLFUVictim checks that the least frequently used key is evicted even
+// when it isn't the oldest.
+func TestLFUVictim(t *testing.T) {
+	p := cache.NewLFU[string]()
+	p.Insert("a", 1)
+	p.Insert("b", 1)
+	p.Touch("a") // "a" is now more frequent than "b"
+
+	victim, ok := p.Victim()
+	if !ok || victim != "b" {
+		t.Errorf("Expected \"b\" to be the LFU victim, got %q", victim)
+	}
+}
+
+// TestTwoQueueGhostPromotion checks that a rehit on a key that was
+// evicted out of the recent FIFO is promoted straight to the frequent
+// list, rather than re-entering as a one-hit wonder.
+func TestTwoQueueGhostPromotion(t *testing.T) {
+	p := cache.NewTwoQueue[string](2)
+	p.Insert("a", 1) // lands in `recent`
+	p.Evict("a")     // evicted; leaves a ghost behind
+
+	p.Insert("a", 1) // ghost rehit: should promote straight to `frequent`
+	p.Insert("b", 1) // new key: lands in `recent`, and is the LRU victim
+
+	victim, ok := p.Victim()
+	if !ok || victim != "b" {
+		t.Errorf("Expected \"b\" to be the victim (recent beats frequent), got %q", victim)
+	}
+}
+
+// TestSieveSecondChance checks that a visited entry is skipped once (its
+// bit cleared) before a later sweep evicts it.
+func TestSieveSecondChance(t *testing.T) {
+	p := cache.NewSieve[string]()
+	p.Insert("a", 1)
+	p.Insert("b", 1)
+	p.Touch("a") // give "a" a second chance
+
+	victim, ok := p.Victim()
+	if !ok || victim != "b" {
+		t.Errorf("Expected \"b\" to be evicted first, got %q", victim)
+	}
+
+	// "a" was visited, so it survived the first sweep; after inserting a new
+	// key "c", "a" should now be evicted since its visited bit was cleared.
+	p.Remove("b")
+	p.Insert("c", 1)
+	victim, ok = p.Victim()
+	if !ok || victim != "a" {
+		t.Errorf("Expected \"a\" to be evicted on the second sweep, got %q", victim)
+	}
+}
+
+// TestARCGhostHitPromotesIntoT2 checks that a B1 ghost hit grows the
+// recency target p and promotes the key straight into T2, and that a
+// subsequent eviction correctly comes from T1 (the only resident key left
+// after the promotion), not T2.
+func TestARCGhostHitPromotesIntoT2(t *testing.T) {
+	p := cache.NewARC[string](2)
+	p.Insert("x", 1) // T1: [x], still a one-time reference
+	p.Insert("a", 1) // T1: [a, x]
+
+	victim, ok := p.Victim()
+	if !ok || victim != "x" {
+		t.Fatalf("Expected \"x\" to be the victim before any ghost hit, got %q", victim)
+	}
+	p.Evict("x") // x was in T1, so this demotes it into B1, not B2
+
+	p.Insert("x", 1) // B1 hit: grows p, promotes x straight into T2
+
+	victim, ok = p.Victim()
+	if !ok || victim != "a" {
+		t.Errorf("Expected \"a\" (left in T1) to be evicted next, got %q", victim)
+	}
+}
+
+// TestCacheWithPolicy checks that a Cache constructed with a non-default
+// Policy defers eviction order to it.
+func TestCacheWithPolicy(t *testing.T) {
+	c := cache.New[string, int](2, cache.WithPolicy[string, int](cache.NewLFU[string]()))
+
+	c.AddWithSize("a", 1, 1)
+	c.AddWithSize("b", 2, 1)
+	c.Get("a") // "a" is now more frequent than "b"
+	c.AddWithSize("c", 3, 1)
+
+	if c.Contains("b") {
+		t.Errorf("Expected \"b\" (least frequently used) to have been evicted")
+	}
+	if !c.Contains("a") {
+		t.Errorf("Expected \"a\" to survive the eviction")
+	}
+}
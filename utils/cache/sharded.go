@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+)
+
+// ShardedCache partitions keys across N independent Caches, so concurrent
+// callers touching different keys don't contend on a single mutex. Use
+// NewSharded to construct one.
+type ShardedCache[K comparable, V any] struct {
+	shards []*Cache[K, V]
+}
+
+// NewSharded constructs a ShardedCache of the given number of shards, each
+// an independent Cache[K, V] of perShardBytes capacity built with opts.
+// shards is clamped to at least 1.
+//
+// If opts includes WithDiskStore, every shard would otherwise open the
+// same manifest - each shard's independent in-memory manifest would then
+// clobber the others' on save, orphaning their blob files. So a
+// shard-index subdirectory of the configured disk dir is appended for
+// each shard, after opts is applied.
+func NewSharded[K comparable, V any](shards int, perShardBytes int, opts ...Option[K, V]) *ShardedCache[K, V] {
+	if 1 > shards {
+		shards = 1
+	}
+	sc := &ShardedCache[K, V]{shards: make([]*Cache[K, V], shards)}
+	for i := range sc.shards {
+		shardOpts := append(append([]Option[K, V]{}, opts...), perShardDiskDir[K, V](i))
+		sc.shards[i] = New[K, V](perShardBytes, shardOpts...)
+	}
+	return sc
+}
+
+// perShardDiskDir rewrites whatever disk directory WithDiskStore set to a
+// subdirectory named after shard, so each shard gets its own manifest.
+func perShardDiskDir[K comparable, V any](shard int) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		if "" != c.diskDir {
+			c.diskDir = filepath.Join(c.diskDir, strconv.Itoa(shard))
+		}
+	}
+}
+
+// Shard returns the Cache responsible for key, so callers that need to
+// operate on a specific shard's Cache (e.g. Client.Prefetch) can do so
+// directly.
+func (sc *ShardedCache[K, V]) Shard(key K) *Cache[K, V] {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%v", key)
+	return sc.shards[h.Sum32()%uint32(len(sc.shards))]
+}
+
+// Add inserts or updates key with value in its shard.
+func (sc *ShardedCache[K, V]) Add(key K, value V) (evicted bool) {
+	return sc.Shard(key).Add(key, value)
+}
+
+// AddWithSize inserts or updates key with value in its shard, accounting
+// size bytes towards that shard's capacity.
+func (sc *ShardedCache[K, V]) AddWithSize(key K, value V, size int) (evicted bool) {
+	return sc.Shard(key).AddWithSize(key, value, size)
+}
+
+// Get looks up key in its shard.
+func (sc *ShardedCache[K, V]) Get(key K) (value V, ok bool) {
+	return sc.Shard(key).Get(key)
+}
+
+// Peek returns key's value from its shard without notifying that shard's
+// Policy of an access.
+func (sc *ShardedCache[K, V]) Peek(key K) (value V, ok bool) {
+	return sc.Shard(key).Peek(key)
+}
+
+// Contains reports whether key is in the cache.
+func (sc *ShardedCache[K, V]) Contains(key K) bool {
+	return sc.Shard(key).Contains(key)
+}
+
+// Remove deletes key from its shard.
+func (sc *ShardedCache[K, V]) Remove(key K) bool {
+	return sc.Shard(key).Remove(key)
+}
+
+// Purge clears every shard.
+func (sc *ShardedCache[K, V]) Purge() {
+	for _, shard := range sc.shards {
+		shard.Purge()
+	}
+}
+
+// Len returns the total number of items across all shards.
+func (sc *ShardedCache[K, V]) Len() int {
+	total := 0
+	for _, shard := range sc.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+// Stats returns a per-shard snapshot of hit/miss/eviction counters and
+// size, in shard order - suitable for JSON dumping.
+func (sc *ShardedCache[K, V]) Stats() []Stats {
+	stats := make([]Stats, len(sc.shards))
+	for i, shard := range sc.shards {
+		stats[i] = shard.Stats()
+	}
+	return stats
+}
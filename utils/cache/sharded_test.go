@@ -0,0 +1,95 @@
+// Test Package cache
+package cache_test
+
+import (
+	"." // imports this current directory so we get the cache package
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// TestShardedCacheDiskStoreSurvivesRestart checks that every shard's
+// demoted keys come back after a simulated restart. Before each shard got
+// its own manifest subdirectory, every shard's diskStore pointed at the
+// same manifest.gob, and whichever shard saved last clobbered the rest -
+// demoted keys from every other shard were silently lost.
+func TestShardedCacheDiskStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	sc1 := cache.NewSharded[string, []byte](4, 1, cache.WithDiskStore[string, []byte](dir, 1<<20))
+
+	// Demote exactly one key per shard: each shard holds 1 byte, so adding
+	// a second key to the same shard evicts the first to disk.
+	demoted := make(map[string]byte)
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("key%d-a", i)
+		shard := sc1.Shard(key)
+		shard.AddWithSize(key, []byte{byte(i)}, 1)
+		shard.AddWithSize(fmt.Sprintf("key%d-b", i), []byte{99}, 1) // evicts key to disk
+		demoted[key] = byte(i)
+	}
+
+	// Simulate a restart: a brand new ShardedCache over the same disk dir.
+	sc2 := cache.NewSharded[string, []byte](4, 1, cache.WithDiskStore[string, []byte](dir, 1<<20))
+
+	for key, want := range demoted {
+		got, ok := sc2.Get(key)
+		if !ok {
+			t.Errorf("Get(%q): expected to recover from its shard's manifest after a restart", key)
+			continue
+		}
+		if !bytes.Equal(got, []byte{want}) {
+			t.Errorf("Get(%q) = %v, want [%d]", key, got, want)
+		}
+	}
+}
+
+func TestShardedCacheDistributesKeys(t *testing.T) {
+	sc := cache.NewSharded[string, int](4, 100)
+
+	for i := 0; i < 20; i++ {
+		sc.AddWithSize(fmt.Sprintf("key%d", i), i, 1)
+	}
+	if 20 != sc.Len() {
+		t.Fatalf("Expected 20 items total, got %d", sc.Len())
+	}
+
+	stats := sc.Stats()
+	if 4 != len(stats) {
+		t.Fatalf("Expected 4 shards of stats, got %d", len(stats))
+	}
+	total := 0
+	for _, s := range stats {
+		total += s.Len
+	}
+	if 20 != total {
+		t.Errorf("Expected stats to account for 20 items, got %d", total)
+	}
+}
+
+func TestShardedCacheGetSameShard(t *testing.T) {
+	sc := cache.NewSharded[string, string](4, 100)
+
+	sc.AddWithSize("a", "apple", 5)
+	got, ok := sc.Get("a")
+	if !ok || "apple" != got {
+		t.Fatalf("Expected to find \"a\" = \"apple\", got %q, %v", got, ok)
+	}
+
+	if _, ok := sc.Get("missing"); ok {
+		t.Errorf("Expected \"missing\" to not be found")
+	}
+
+	stats := sc.Stats()
+	var hits, misses uint64
+	for _, s := range stats {
+		hits += s.Hits
+		misses += s.Misses
+	}
+	if 1 != hits {
+		t.Errorf("Expected 1 hit across all shards, got %d", hits)
+	}
+	if 1 != misses {
+		t.Errorf("Expected 1 miss across all shards, got %d", misses)
+	}
+}
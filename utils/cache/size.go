@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps a recognized suffix to its byte multiplier. Both decimal
+// (KB/MB/GB) and binary (KiB/MiB/GiB) spellings are accepted, and both
+// are treated as powers of 1024 - nobody sizing a cache actually means
+// 1000-byte kilobytes.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+}
+
+// ParseSize parses a human-readable byte size like "64MB" or "1GB" into a
+// number of bytes, for flags like --cache-size=200MB. A bare number with
+// no suffix is interpreted as a count of bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if "" == s {
+		return 0, fmt.Errorf("cache: empty size")
+	}
+
+	i := 0
+	for i < len(s) && (('0' <= s[i] && s[i] <= '9') || '.' == s[i]) {
+		i++
+	}
+	numPart := s[:i]
+	suffix := strings.ToUpper(strings.TrimSpace(s[i:]))
+	if "" == numPart {
+		return 0, fmt.Errorf("cache: invalid size %q", s)
+	}
+
+	mult, ok := sizeUnits[suffix]
+	if !ok {
+		return 0, fmt.Errorf("cache: unrecognized size suffix %q in %q", suffix, s)
+	}
+
+	value, err := strconv.ParseFloat(numPart, 64)
+	if nil != err {
+		return 0, fmt.Errorf("cache: invalid size %q: %w", s, err)
+	}
+	return int64(value * float64(mult)), nil
+}
@@ -0,0 +1,37 @@
+// Test Package cache
+package cache_test
+
+import (
+	"." // imports this current directory so we get the cache package
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"0":      0,
+		"512":    512,
+		"64MB":   64 << 20,
+		"1GB":    1 << 30,
+		"1.5MB":  int64(1.5 * (1 << 20)),
+		"200mb":  200 << 20,
+		" 2 GB ": 2 << 30,
+	}
+	for in, want := range cases {
+		got, err := cache.ParseSize(in)
+		if nil != err {
+			t.Errorf("ParseSize(%q): unexpected error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseSizeErrors(t *testing.T) {
+	for _, in := range []string{"", "MB", "abc", "10XB"} {
+		if _, err := cache.ParseSize(in); nil == err {
+			t.Errorf("ParseSize(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Test Package
+package http_test
+
+import (
+	"." // imports the current directory so we get the http package
+	"../cache"
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// rewriteTransport redirects every request to srv, so Client.Fetch's
+// hard-coded "https://"+host+path URLs can be exercised against a local
+// httptest.Server instead of the network.
+type rewriteTransport struct {
+	srvURL string
+}
+
+func (t rewriteTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	u := *req.URL
+	srv, err := nethttp.NewRequest(req.Method, t.srvURL+u.Path, req.Body)
+	if nil != err {
+		return nil, err
+	}
+	srv.Header = req.Header
+	return nethttp.DefaultTransport.RoundTrip(srv)
+}
+
+func newTestClient(srv *httptest.Server) *http.Client {
+	c := http.NewClient()
+	c.HTTPClient = &nethttp.Client{Transport: rewriteTransport{srvURL: srv.URL}}
+	return c
+}
+
+// TestClientFetchAndRevalidate checks the basic Fetched -> Fresh/Revalidated
+// lifecycle: a first Fetch retrieves and caches a response, and a second
+// Fetch reuses it - either without a request (if still fresh) or via a
+// 304 (once max-age has lapsed, here forced by the server itself).
+func TestClientFetchAndRevalidate(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if "\"v1\"" == r.Header.Get("If-None-Match") {
+			w.WriteHeader(nethttp.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "\"v1\"")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte("hit " + strconv.Itoa(int(n))))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	responses := cache.New[string, *http.Response](1 << 20)
+	ctx := context.Background()
+
+	resp, status, err := client.Fetch(ctx, "example.com", "/thing", responses)
+	if nil != err {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if http.Fetched != status {
+		t.Errorf("expected Fetched, got %v", status)
+	}
+	if "hit 1" != string(resp.Body) {
+		t.Errorf("unexpected body: %q", resp.Body)
+	}
+
+	resp, status, err = client.Fetch(ctx, "example.com", "/thing", responses)
+	if nil != err {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if http.Revalidated != status {
+		t.Errorf("expected Revalidated, got %v", status)
+	}
+	if "hit 1" != string(resp.Body) {
+		t.Errorf("expected the cached body to be reused, got %q", resp.Body)
+	}
+	if 2 != atomic.LoadInt32(&hits) {
+		t.Errorf("expected 2 requests to reach the server, got %d", hits)
+	}
+}
+
+// TestClientFetchFresh checks that a response within its max-age is served
+// from cache without another request at all.
+func TestClientFetchFresh(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte("fresh"))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	responses := cache.New[string, *http.Response](1 << 20)
+	ctx := context.Background()
+
+	if _, status, err := client.Fetch(ctx, "example.com", "/thing", responses); nil != err || http.Fetched != status {
+		t.Fatalf("first Fetch: status %v, err %v", status, err)
+	}
+	if _, status, err := client.Fetch(ctx, "example.com", "/thing", responses); nil != err || http.Fresh != status {
+		t.Fatalf("second Fetch: expected Fresh, got status %v, err %v", status, err)
+	}
+	if 1 != atomic.LoadInt32(&hits) {
+		t.Errorf("expected exactly 1 request to reach the server, got %d", hits)
+	}
+}
+
+// TestClientFetchFreshWithMustRevalidate checks that must-revalidate
+// doesn't suppress freshness on its own: a response within its max-age is
+// still served from cache without another request, even though
+// must-revalidate is also set. must-revalidate only forbids serving it
+// stale without revalidating once max-age has actually lapsed.
+func TestClientFetchFreshWithMustRevalidate(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=3600, must-revalidate")
+		w.Write([]byte("fresh"))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	responses := cache.New[string, *http.Response](1 << 20)
+	ctx := context.Background()
+
+	if _, status, err := client.Fetch(ctx, "example.com", "/thing", responses); nil != err || http.Fetched != status {
+		t.Fatalf("first Fetch: status %v, err %v", status, err)
+	}
+	if _, status, err := client.Fetch(ctx, "example.com", "/thing", responses); nil != err || http.Fresh != status {
+		t.Fatalf("second Fetch: expected Fresh, got status %v, err %v", status, err)
+	}
+	if 1 != atomic.LoadInt32(&hits) {
+		t.Errorf("expected exactly 1 request to reach the server, got %d", hits)
+	}
+}
+
+// TestClientFollowsRedirect checks that a 302 is followed to the final
+// resource, rather than being returned as-is.
+func TestClientFollowsRedirect(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if "/old" == r.URL.Path {
+			nethttp.Redirect(w, r, "/new", nethttp.StatusFound)
+			return
+		}
+		w.Write([]byte("new location"))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	responses := cache.New[string, *http.Response](1 << 20)
+
+	resp, status, err := client.Fetch(context.Background(), "example.com", "/old", responses)
+	if nil != err {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if http.Fetched != status {
+		t.Errorf("expected Fetched, got %v", status)
+	}
+	if "new location" != string(resp.Body) {
+		t.Errorf("expected the redirect to be followed, got %q", resp.Body)
+	}
+}
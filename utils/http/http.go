@@ -1,22 +1,38 @@
 /*
-HTTP GET function.
+HTTP fetching, with and without caching.
 */
 
 package http
 
 import (
+	"../cache"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// HTTP_GET performs a single, uncached GET request. It predates Client
+// and is kept for simple one-off fetches that don't need HTTP caching
+// semantics; Client.Fetch is preferred for anything that talks to a
+// cache.Cache.
+//
 // Arguments: hostname and URL
-// Returns: status, response and file contents
+// Returns: status, response headers and file contents
 func HTTP_GET(host string,
 	URL string,
 	headers map[string]string) (int, http.Header, []byte) {
 
 	req, err := http.NewRequest("GET", "https://"+host+URL, nil)
+	if nil != err {
+		fmt.Println("Error:", err)
+		return 0, http.Header{}, []byte("")
+	}
 	for key, value := range headers {
 		req.Header.Add(key, value)
 	}
@@ -24,7 +40,7 @@ func HTTP_GET(host string,
 	resp, err := client.Do(req)
 	if nil != err {
 		fmt.Println("Error:", err)
-		return 0, resp.Header, []byte("")
+		return 0, http.Header{}, []byte("")
 	}
 
 	defer resp.Body.Close() // close response after we have read all the data
@@ -32,7 +48,313 @@ func HTTP_GET(host string,
 	body, err := ioutil.ReadAll(resp.Body)
 	if nil != err {
 		fmt.Println("Error:", err)
-		return 0, resp.Header, body
+		return resp.StatusCode, resp.Header, body
 	}
 	return resp.StatusCode, resp.Header, body
 }
+
+// Status describes how a Client.Fetch call satisfied a request.
+type Status int
+
+const (
+	Fetched     Status = iota // a new response was retrieved from the server
+	Fresh                     // the cached response was still within its freshness lifetime; no request was sent
+	Revalidated               // the server confirmed the cached response with a 304
+	Stale                     // the cached response could not be revalidated, and fetching a new one failed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Fetched:
+		return "Fetched"
+	case Fresh:
+		return "Fresh"
+	case Revalidated:
+		return "Revalidated"
+	case Stale:
+		return "Stale"
+	default:
+		return "Unknown"
+	}
+}
+
+// Response is a cached HTTP response, together with the bookkeeping
+// needed to decide whether it's still fresh and how to revalidate it
+// once it isn't.
+type Response struct {
+	StatusCode     int
+	Header         http.Header
+	Body           []byte
+	FetchedAt      time.Time
+	MaxAge         int // seconds from FetchedAt; -1 if the server didn't send one
+	HasExpires     bool
+	Expires        time.Time
+	NoStore        bool
+	MustRevalidate bool
+	VaryStar       bool // Vary: * - per RFC 7234 4.1, never reusable from cache
+}
+
+// ETag returns the response's ETag header, for building a conditional
+// If-None-Match revalidation request.
+func (r *Response) ETag() string { return r.Header.Get("ETag") }
+
+// LastModified returns the response's Last-Modified header, for building
+// a conditional If-Modified-Since revalidation request.
+func (r *Response) LastModified() string { return r.Header.Get("Last-Modified") }
+
+// fresh reports whether r can still be served without contacting the
+// server, per the Cache-Control/Expires/Vary directives it arrived with.
+//
+// must-revalidate only forbids serving r once it's stale without
+// revalidating first (RFC 7234 §5.2.2.1) - the normal stale->revalidate
+// path already does that - so it must not suppress freshness on its own
+// while r is still within max-age/Expires.
+func (r *Response) fresh() bool {
+	if r.NoStore || r.VaryStar {
+		return false
+	}
+	if 0 <= r.MaxAge {
+		return time.Since(r.FetchedAt) < time.Duration(r.MaxAge)*time.Second
+	}
+	if r.HasExpires {
+		return time.Now().Before(r.Expires)
+	}
+	return false
+}
+
+// Client is a conditional HTTP fetcher: it consults a cache.Cache for a
+// previous response before making a request, injects revalidation
+// headers when the cached copy needs one, follows redirects, and retries
+// on 429/503 per the server's Retry-After header.
+type Client struct {
+	HTTPClient   *http.Client
+	MaxRedirects int
+	MaxRetries   int
+}
+
+// NewClient constructs a Client with sane defaults: a fresh http.Client,
+// up to 10 redirect hops, and up to 5 Retry-After retries.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient:   &http.Client{},
+		MaxRedirects: 10,
+		MaxRetries:   5,
+	}
+}
+
+// Fetch retrieves host+path, consulting and updating ca to avoid
+// redundant transfers: a fresh cached response is returned without a
+// request, a stale one is revalidated with If-None-Match/
+// If-Modified-Since, redirects are followed automatically, and
+// Retry-After on 429/503 is honored with exponential backoff as a
+// fallback. ctx can cancel a request or an in-progress retry wait.
+func (c *Client) Fetch(ctx context.Context, host, path string, ca *cache.Cache[string, *Response]) (*Response, Status, error) {
+	key := host + path
+	cached, hasCached := ca.Get(key)
+	if hasCached && cached.fresh() {
+		return cached, Fresh, nil
+	}
+
+	reqHeaders := make(map[string]string)
+	if hasCached {
+		if etag := cached.ETag(); "" != etag {
+			reqHeaders["If-None-Match"] = etag
+		}
+		if lastMod := cached.LastModified(); "" != lastMod {
+			reqHeaders["If-Modified-Since"] = lastMod
+		}
+	}
+
+	target := "https://" + host + path
+	var resp *http.Response
+	for hop, retry := 0, 0; ; {
+		r, err := c.doOnce(ctx, target, reqHeaders)
+		if nil != err {
+			return cached, Stale, err
+		}
+
+		if isRedirect(r.StatusCode) {
+			r.Body.Close()
+			hop++
+			if hop > c.MaxRedirects {
+				return cached, Stale, fmt.Errorf("http: too many redirects (> %d) fetching %s", c.MaxRedirects, target)
+			}
+			loc := r.Header.Get("Location")
+			if "" == loc {
+				return cached, Stale, fmt.Errorf("http: redirect from %s has no Location header", target)
+			}
+			next, err := resolveRedirect(target, loc)
+			if nil != err {
+				return cached, Stale, err
+			}
+			target = next
+			continue
+		}
+
+		if 429 == r.StatusCode || 503 == r.StatusCode {
+			r.Body.Close()
+			retry++
+			if retry > c.MaxRetries {
+				return cached, Stale, fmt.Errorf("http: giving up after %d retries fetching %s", c.MaxRetries, target)
+			}
+			wait := retryDelay(r.Header.Get("Retry-After"), retry)
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return cached, Stale, ctx.Err()
+			}
+			continue
+		}
+
+		resp = r
+		break
+	}
+	defer resp.Body.Close()
+
+	if 304 == resp.StatusCode {
+		if !hasCached {
+			return nil, Stale, fmt.Errorf("http: server returned 304 for %s with nothing cached", target)
+		}
+		cached.FetchedAt = time.Now()
+		maxAge, hasExpires, expires, noStore, mustRevalidate, varyStar := parseCacheDirectives(resp.Header)
+		cached.MaxAge, cached.HasExpires, cached.Expires = maxAge, hasExpires, expires
+		cached.NoStore, cached.MustRevalidate, cached.VaryStar = noStore, mustRevalidate, varyStar
+		ca.AddWithSize(key, cached, len(cached.Body))
+		return cached, Revalidated, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return cached, Stale, err
+	}
+
+	fetched := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		FetchedAt:  time.Now(),
+	}
+	fetched.MaxAge, fetched.HasExpires, fetched.Expires,
+		fetched.NoStore, fetched.MustRevalidate, fetched.VaryStar =
+		parseCacheDirectives(resp.Header)
+	if !fetched.NoStore {
+		ca.AddWithSize(key, fetched, len(body))
+	}
+	return fetched, Fetched, nil
+}
+
+// Prefetch fetches host+path for every entry in paths, using up to
+// concurrency worker goroutines, and populates the shard of sc responsible
+// for each URL. It's meant for warming a ShardedCache with thousands of
+// assets without serializing on a single Cache's mutex. The returned slice
+// has one error per path, in the same order as paths (nil where the fetch
+// succeeded); a failure for one URL doesn't stop the others.
+func (c *Client) Prefetch(ctx context.Context, host string, paths []string, sc *cache.ShardedCache[string, *Response], concurrency int) []error {
+	if 1 > concurrency {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				shard := sc.Shard(host + paths[i])
+				_, _, err := c.Fetch(ctx, host, paths[i], shard)
+				errs[i] = err
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+func (c *Client) doOnce(ctx context.Context, requestURL string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if nil != err {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
+}
+
+// resolveRedirect resolves a Location header against the URL it came
+// from, since it may be relative.
+func resolveRedirect(from, location string) (string, error) {
+	base, err := url.Parse(from)
+	if nil != err {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if nil != err {
+		return "", fmt.Errorf("http: invalid Location header %q: %w", location, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// parseCacheDirectives reads Cache-Control, Expires and Vary out of
+// headers. maxAge is -1 if Cache-Control had no max-age directive.
+func parseCacheDirectives(headers http.Header) (maxAge int, hasExpires bool, expires time.Time, noStore, mustRevalidate, varyStar bool) {
+	maxAge = -1
+	varyStar = "*" == strings.TrimSpace(headers.Get("Vary"))
+
+	for _, directive := range strings.Split(headers.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		switch {
+		case "no-store" == directive:
+			noStore = true
+		case "must-revalidate" == directive:
+			mustRevalidate = true
+		case strings.HasPrefix(directive, "max-age="):
+			if age, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); nil == err {
+				maxAge = age
+			}
+		}
+	}
+
+	if 0 > maxAge {
+		if when, err := http.ParseTime(headers.Get("Expires")); nil == err {
+			expires = when
+			hasExpires = true
+		}
+	}
+	return maxAge, hasExpires, expires, noStore, mustRevalidate, varyStar
+}
+
+// retryDelay computes how long to wait before retrying, honoring a
+// Retry-After header (either seconds or an HTTP date) if present, and
+// falling back to exponential backoff (1s, 2s, 4s, ...) otherwise.
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if "" != retryAfter {
+		if secs, err := strconv.Atoi(retryAfter); nil == err {
+			return time.Duration(secs) * time.Second
+		}
+		if when, err := http.ParseTime(retryAfter); nil == err {
+			if wait := time.Until(when); 0 < wait {
+				return wait
+			}
+		}
+	}
+	return time.Duration(1<<uint(attempt-1)) * time.Second
+}
@@ -0,0 +1,46 @@
+// Test Package
+package http_test
+
+import (
+	"." // imports the current directory so we get the http package
+	"../cache"
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientPrefetch(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("body for " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	sc := cache.NewSharded[string, *http.Response](4, 1<<20)
+
+	paths := []string{"/a", "/b", "/c", "/d", "/e"}
+	errs := client.Prefetch(context.Background(), "example.com", paths, sc, 3)
+	for i, err := range errs {
+		if nil != err {
+			t.Errorf("Prefetch(%s): unexpected error: %v", paths[i], err)
+		}
+	}
+	if int32(len(paths)) != atomic.LoadInt32(&requests) {
+		t.Errorf("Expected %d requests, got %d", len(paths), requests)
+	}
+
+	for _, p := range paths {
+		resp, ok := sc.Get("example.com" + p)
+		if !ok {
+			t.Errorf("Expected %q to be cached after Prefetch", p)
+			continue
+		}
+		if "body for "+p != string(resp.Body) {
+			t.Errorf("Expected body for %q, got %q", p, resp.Body)
+		}
+	}
+}